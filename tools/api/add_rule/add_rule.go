@@ -1,7 +1,6 @@
 package main
 
 import (
-	"crypto/tls"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -10,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 type Data struct {
@@ -44,10 +44,24 @@ type GetPayload struct {
 }
 
 var (
-	xAPIKey           string
-	jsonFilePath      string
-	urlHostname       string
-	responseDirectory string
+	xAPIKey             string
+	jsonFilePath        string
+	urlHostname         string
+	responseDirectory   string
+	templatePath        string
+	concurrency         int
+	dryRun              bool
+	strictSchema        bool
+	rps                 float64
+	httpTimeout         time.Duration
+	maxIdleConns        int
+	maxIdleConnsPerHost int
+	idleConnTimeout     time.Duration
+	caCertPath          string
+	clientCertPath      string
+	clientKeyPath       string
+	insecure            bool
+	proxyURL            string
 )
 
 func init() {
@@ -55,10 +69,23 @@ func init() {
 	flag.StringVar(&jsonFilePath, "json-file-path", "", "Path to the JSON file or directory containing JSON files")
 	flag.StringVar(&urlHostname, "url-hostname", "", "Hostname of the URL")
 	flag.StringVar(&responseDirectory, "response-file-dir", "", "Directory to save response files")
-	flag.Parse()
+	flag.StringVar(&templatePath, "template", "", "Path to a YAML/JSON file overriding the default correlation fields (defaults and per-rule overrides)")
+	flag.IntVar(&concurrency, "concurrency", 4, "Number of JSON files to process concurrently")
+	flag.BoolVar(&dryRun, "dry-run", false, "Diff each computed correlation against the deployed version instead of saving it")
+	flag.BoolVar(&strictSchema, "strict", false, "Reject input JSON files with fields not recognized by the AlterixRule schema")
+	flag.Float64Var(&rps, "rps", 0, "Maximum requests per second sent to the SIEM endpoint (0 = unlimited)")
+	flag.DurationVar(&httpTimeout, "http-timeout", 30*time.Second, "Timeout for a single HTTP request")
+	flag.IntVar(&maxIdleConns, "max-idle-conns", 100, "Maximum idle HTTP connections to keep open")
+	flag.IntVar(&maxIdleConnsPerHost, "max-idle-conns-per-host", 10, "Maximum idle HTTP connections to keep open per host")
+	flag.DurationVar(&idleConnTimeout, "idle-conn-timeout", 90*time.Second, "How long an idle HTTP connection is kept open before being closed")
+	flag.StringVar(&caCertPath, "ca-cert", "", "Path to a PEM CA bundle used to verify the SIEM's certificate")
+	flag.StringVar(&clientCertPath, "client-cert", "", "Path to a PEM client certificate for mTLS authentication")
+	flag.StringVar(&clientKeyPath, "client-key", "", "Path to the PEM private key matching -client-cert")
+	flag.BoolVar(&insecure, "insecure", false, "Disable TLS certificate verification (unsafe, for dev/test SIEM instances only)")
+	flag.StringVar(&proxyURL, "proxy", "", "Proxy URL to route SIEM requests through (defaults to the HTTP(S)_PROXY environment variables)")
 }
 
-func SaveRequest(xAPIKey, saveFullURL, method, responseDirectory string, savePayload SavePayload) error {
+func SaveRequest(client *http.Client, xAPIKey, saveFullURL, method, responseDirectory string, savePayload SavePayload) error {
 	savePayloadBytes, err := json.Marshal(savePayload)
 	if err != nil {
 		return fmt.Errorf("error marshaling JSON payload: %w", err)
@@ -72,13 +99,9 @@ func SaveRequest(xAPIKey, saveFullURL, method, responseDirectory string, savePay
 	req.Header.Add("x-api-key", xAPIKey)
 	req.Header.Add("Content-Type", "application/json")
 
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
-	client := &http.Client{Transport: transport}
-	// Send HTTP request and get the response
-	res, err := client.Do(req)
-	if err != nil || res.StatusCode != 200 {
+	// Send the HTTP request, retrying on 429/5xx with backoff.
+	res, err := doWithRetry(client, req, defaultRetryConfig)
+	if err != nil {
 		return fmt.Errorf("error sending HTTP request: %w", err)
 	}
 	defer res.Body.Close()
@@ -88,6 +111,10 @@ func SaveRequest(xAPIKey, saveFullURL, method, responseDirectory string, savePay
 		return fmt.Errorf("error reading HTTP response: %w", err)
 	}
 
+	if res.StatusCode != 200 {
+		return fmt.Errorf("error sending HTTP request: unexpected status %d: %s", res.StatusCode, body)
+	}
+
 	// Decode the JSON response
 	var jsonResponse map[string]interface{}
 	err = json.Unmarshal(body, &jsonResponse)
@@ -113,54 +140,88 @@ func SaveRequest(xAPIKey, saveFullURL, method, responseDirectory string, savePay
 	fmt.Printf("Response received and saved to %s\n", responseFilePath)
 	return nil
 }
-func GetRequest(xAPIKey, getFullURL, method string, getPayload GetPayload) error {
+
+// fetchCorrelationList calls GetCorrelationList and returns the decoded
+// JSON response, shared by GetRequest and FetchCorrelation.
+func fetchCorrelationList(client *http.Client, xAPIKey, getFullURL, method string, getPayload GetPayload) (map[string]interface{}, error) {
 	getPayloadBytes, err := json.Marshal(getPayload)
 	if err != nil {
-		return fmt.Errorf("error marshaling JSON payload: %w", err)
+		return nil, fmt.Errorf("error marshaling JSON payload: %w", err)
 	}
 
 	req, err := http.NewRequest(method, getFullURL, strings.NewReader(string(getPayloadBytes)))
 	if err != nil {
-		return fmt.Errorf("error creating HTTP request: %w", err)
+		return nil, fmt.Errorf("error creating HTTP request: %w", err)
 	}
 
 	req.Header.Add("x-api-key", xAPIKey)
 	req.Header.Add("Content-Type", "application/json")
 
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
-	client := &http.Client{Transport: transport}
-	// Send HTTP request and get the response
-	res, err := client.Do(req)
-	if err != nil || res.StatusCode != 200 {
-		return fmt.Errorf("error sending HTTP request: %w", err)
+	// Send the HTTP request, retrying on 429/5xx with backoff.
+	res, err := doWithRetry(client, req, defaultRetryConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error sending HTTP request: %w", err)
 	}
 	defer res.Body.Close()
 
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		return fmt.Errorf("error reading HTTP response: %w", err)
+		return nil, fmt.Errorf("error reading HTTP response: %w", err)
+	}
+
+	if res.StatusCode != 200 {
+		return nil, fmt.Errorf("error sending HTTP request: unexpected status %d: %s", res.StatusCode, body)
 	}
 
 	// Decode the JSON response
 	var jsonResponse map[string]interface{}
-	err = json.Unmarshal(body, &jsonResponse)
+	if err := json.Unmarshal(body, &jsonResponse); err != nil {
+		return nil, fmt.Errorf("error decoding JSON response: %w", err)
+	}
+	return jsonResponse, nil
+}
+
+func GetRequest(client *http.Client, xAPIKey, getFullURL, method string, getPayload GetPayload) error {
+	jsonResponse, err := fetchCorrelationList(client, xAPIKey, getFullURL, method, getPayload)
 	if err != nil {
-		return fmt.Errorf("error decoding JSON response: %w", err)
+		return err
 	}
 
-	if items, ok := jsonResponse["Items"].([]interface{}); ok {
-		itemsCount := len(items)
-		if itemsCount > 0 {
-			return fmt.Errorf("rule is already exist: %s", getPayload.Filter)
-		}
+	if items, ok := jsonResponse["Items"].([]interface{}); ok && len(items) > 0 {
+		return fmt.Errorf("rule is already exist: %s", getPayload.Filter)
 	}
 
 	return nil
 }
 
-func writeJSONToFile(filename string, data map[string]interface{}) error {
+// FetchCorrelation calls GetCorrelationList and returns the remote
+// correlation matching getPayload.Filter, or nil if no match was found.
+// Used by -dry-run to diff the locally computed correlation against what
+// is actually deployed in the SIEM.
+func FetchCorrelation(client *http.Client, xAPIKey, getFullURL, method string, getPayload GetPayload) (*Correlation, error) {
+	jsonResponse, err := fetchCorrelationList(client, xAPIKey, getFullURL, method, getPayload)
+	if err != nil {
+		return nil, err
+	}
+
+	items, ok := jsonResponse["Items"].([]interface{})
+	if !ok || len(items) == 0 {
+		return nil, nil
+	}
+
+	itemBytes, err := json.Marshal(items[0])
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling remote correlation: %w", err)
+	}
+
+	var remote Correlation
+	if err := json.Unmarshal(itemBytes, &remote); err != nil {
+		return nil, fmt.Errorf("error decoding remote correlation: %w", err)
+	}
+	return &remote, nil
+}
+
+func writeJSONToFile(filename string, data interface{}) error {
 	file, err := os.Create(filename)
 	if err != nil {
 		return err
@@ -176,163 +237,115 @@ func writeJSONToFile(filename string, data map[string]interface{}) error {
 	return nil
 }
 
-func processJSONPayload(payload map[string]interface{}) (SavePayload, GetPayload, error) {
+// buildPayloads turns a validated AlterixRule into the SavePayload/GetPayload
+// pair sent to the SIEM, applying tpl's defaults and overrides.
+func buildPayloads(rule AlterixRule, tpl *CorrelationTemplate) (SavePayload, GetPayload) {
 	var savePayload SavePayload
-	var getPayload GetPayload
+	savePayload.Correlation.Name = rule.Name
+	savePayload.Correlation.Description = rule.Description
 
-	if query, ok := payload["query"].(map[string]interface{}); ok {
-		savePayload.Correlation.Name = getStringFromMap(query, "Name")
-		savePayload.Correlation.Description = getStringFromMap(query, "Description")
+	savePayload.Correlation.Tags = rule.Tags
+	if savePayload.Correlation.Tags == nil {
+		savePayload.Correlation.Tags = []string{}
+	}
 
-		// Check if "Tags" exists in the map and is not nil
-		if tags, ok := query["Tags"].([]interface{}); ok && tags != nil {
-			savePayload.Correlation.Tags = toStringSlice(tags)
-		} else {
-			savePayload.Correlation.Tags = []string{}
-		}
+	defaults := resolveDefaults(tpl, rule.ID, rule.Level, savePayload.Correlation.Tags)
 
-		savePayload.Correlation.MaxAlertCount = 5
-		savePayload.Correlation.RiskLevel = getIntFromMap(query, "RiskLevel")
-		savePayload.Correlation.CorrelationType = "Interface IQueryCorrelation"
-		savePayload.Correlation.Data.TimeFrameValue = 5
-		savePayload.Correlation.Data.TimeFrameType = "minutes"
-		savePayload.Correlation.Data.RuleType = "any"
-		savePayload.Correlation.Data.QueryCorrelationAlertType = "WhenOneOrMoreRow"
-		savePayload.Correlation.Data.QueryID = getStringFromMap(query, "ID")
-		savePayload.Correlation.Data.Query = getStringFromMap(query, "Query")
-		savePayload.Correlation.Enabled = false
-		savePayload.Correlation.Message = savePayload.Correlation.Name
+	savePayload.Correlation.MaxAlertCount = *defaults.MaxAlertCount
+	if defaults.RiskLevel != nil {
+		savePayload.Correlation.RiskLevel = *defaults.RiskLevel
 	} else {
-		fmt.Println("Error: Unable to parse 'query' from payload.")
+		savePayload.Correlation.RiskLevel = rule.RiskLevel
 	}
-	// Update the SmartRestRequestContext field in the payload
+	savePayload.Correlation.CorrelationType = *defaults.CorrelationType
+	savePayload.Correlation.Data.TimeFrameValue = *defaults.TimeFrameValue
+	savePayload.Correlation.Data.TimeFrameType = *defaults.TimeFrameType
+	savePayload.Correlation.Data.RuleType = *defaults.RuleType
+	savePayload.Correlation.Data.QueryCorrelationAlertType = *defaults.QueryCorrelationAlertType
+	savePayload.Correlation.Data.QueryID = rule.ID
+	savePayload.Correlation.Data.Query = rule.Query
+	savePayload.Correlation.Enabled = *defaults.Enabled
+	savePayload.Correlation.Message = savePayload.Correlation.Name
 	savePayload.SmartRestRequestContext = "-<SmartRestRequestContext>-"
 
-	getPayload.Filter = `"` + savePayload.Correlation.Name + `"`
-	getPayload.SmartRestRequestContext = "-<SmartRestRequestContext>-"
-
-	return savePayload, getPayload, nil
-}
-
-// getStringFromMap retrieves a string value from a map with error handling
-func getStringFromMap(m map[string]interface{}, key string) string {
-	if value, ok := m[key].(string); ok {
-		return value
+	getPayload := GetPayload{
+		Filter:                  `"` + savePayload.Correlation.Name + `"`,
+		SmartRestRequestContext: "-<SmartRestRequestContext>-",
 	}
-	return "" // or handle the case where the value is not a string
-}
 
-// getIntFromMap retrieves an integer value from a map with error handling
-func getIntFromMap(m map[string]interface{}, key string) int {
-	if value, ok := m[key].(float64); ok {
-		return int(value)
-	}
-	return 0 // or handle the case where the value is not a float64
-}
-
-// toStringSlice converts an []interface{} to a []string
-func toStringSlice(slice []interface{}) []string {
-	result := make([]string, len(slice))
-	for i, v := range slice {
-		if str, ok := v.(string); ok {
-			result[i] = str
-		} else {
-			// Handle the case where an element in the slice is not a string
-			result[i] = "" // or return an error, depending on your requirements
-		}
-	}
-	return result
+	return savePayload, getPayload
 }
 
 func main() {
+	flag.Parse()
+
 	if xAPIKey == "" || jsonFilePath == "" || urlHostname == "" || responseDirectory == "" {
 		fmt.Println("Usage: go run add_rule.go -x-api-key <xAPIKey> -json-file-path <jsonFilePath> -url-hostname <urlHostname> -response-file-dir <responseDirectory>")
 		flag.PrintDefaults()
 		return
 	}
 
-	saveURLPath := "/api/DpConnection/CallByInterfaceApi/?interfaceCode=ICSiemManagerCorrelationAct&methodName=AddOrUpdateCorrelation&culture=en"
-	getURLPath := "/api/DpConnection/CallByInterfaceApi/?interfaceCode=ICSiemManagerCorrelationAct&methodName=GetCorrelationList&culture=en"
-
-	stat, err := os.Stat(jsonFilePath)
+	jsonFiles, err := collectJSONFiles(jsonFilePath)
 	if err != nil {
-		fmt.Println("Error opening JSON file or directory:", err)
+		fmt.Println("Error reading JSON files:", err)
 		return
 	}
 
-	if stat.IsDir() {
-		err = filepath.Walk(jsonFilePath, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			if !info.IsDir() && strings.HasSuffix(info.Name(), ".json") {
-				jsonFile, err := os.Open(path)
-				if err != nil {
-					return err
-				}
-				defer jsonFile.Close()
-
-				var payload map[string]interface{}
-				decoder := json.NewDecoder(jsonFile)
-				if err := decoder.Decode(&payload); err != nil {
-					fmt.Println("Error decoding JSON file:", err)
-					return nil
-				}
-
-				savePayload, getPayload, err := processJSONPayload(payload)
-				if err != nil {
-					fmt.Println("Error processing JSON payload:", err)
-					return nil
-				}
-
-				saveFullURL := fmt.Sprintf("https://%s%s", urlHostname, saveURLPath)
-				getFullURL := fmt.Sprintf("https://%s%s", urlHostname, getURLPath)
-
-				err = GetRequest(xAPIKey, getFullURL, "POST", getPayload)
-				if err == nil {
-					if err := SaveRequest(xAPIKey, saveFullURL, "POST", responseDirectory, savePayload); err != nil {
-						fmt.Println(err)
-					}
-				} else {
-					fmt.Println(err)
-				}
-			}
-			return nil
-		})
-		if err != nil {
-			fmt.Println("Error reading JSON files:", err)
-		}
-	} else {
-		jsonFile, err := os.Open(jsonFilePath)
+	var tpl *CorrelationTemplate
+	if templatePath != "" {
+		tpl, err = loadTemplate(templatePath)
 		if err != nil {
-			fmt.Println("Error opening JSON file:", err)
+			fmt.Println(err)
 			return
 		}
-		defer jsonFile.Close()
+	}
 
-		var payload map[string]interface{}
-		decoder := json.NewDecoder(jsonFile)
-		if err := decoder.Decode(&payload); err != nil {
-			fmt.Println("Error decoding JSON file:", err)
-			return
-		}
+	client, err := newHTTPClient(httpClientConfig{
+		Timeout:             httpTimeout,
+		MaxIdleConns:        maxIdleConns,
+		MaxIdleConnsPerHost: maxIdleConnsPerHost,
+		IdleConnTimeout:     idleConnTimeout,
+		CACertPath:          caCertPath,
+		ClientCertPath:      clientCertPath,
+		ClientKeyPath:       clientKeyPath,
+		Insecure:            insecure,
+		ProxyURL:            proxyURL,
+	})
+	if err != nil {
+		fmt.Println("Error configuring HTTP client:", err)
+		return
+	}
+	limiter := newRateLimiter(rps)
 
-		savePayload, getPayload, err := processJSONPayload(payload)
-		if err != nil {
-			fmt.Println("Error processing JSON payload:", err)
-			return
-		}
+	report := processFiles(jsonFiles, client, limiter, tpl, urlHostname, xAPIKey, responseDirectory, concurrency, dryRun, strictSchema)
+	if err := writeSummary(responseDirectory, report); err != nil {
+		fmt.Println("Error writing summary report:", err)
+	}
 
-		saveFullURL := fmt.Sprintf("https://%s%s", urlHostname, saveURLPath)
-		getFullURL := fmt.Sprintf("https://%s%s", urlHostname, getURLPath)
+	fmt.Printf("Processed %d file(s): %d succeeded, %d failed\n", report.Total, report.Success, report.Failed)
+}
 
-		err = GetRequest(xAPIKey, getFullURL, "POST", getPayload)
-		if err == nil {
-			if err := SaveRequest(xAPIKey, saveFullURL, "POST", responseDirectory, savePayload); err != nil {
-				fmt.Println(err)
-			}
-		} else {
-			fmt.Println(err)
-		}
+// collectJSONFiles returns every .json file to process for path, which may
+// be a single file or a directory walked recursively.
+func collectJSONFiles(path string) ([]string, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening JSON file or directory: %w", err)
+	}
+
+	if !stat.IsDir() {
+		return []string{path}, nil
 	}
+
+	var files []string
+	err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(info.Name(), ".json") {
+			files = append(files, p)
+		}
+		return nil
+	})
+	return files, err
 }
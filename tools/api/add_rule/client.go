@@ -0,0 +1,85 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// httpClientConfig holds the knobs used to build the shared *http.Client
+// that every worker uses when talking to the SIEM API.
+type httpClientConfig struct {
+	Timeout             time.Duration
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+
+	// CACertPath, when set, is a PEM bundle used instead of the system root
+	// CAs to verify the SIEM's certificate.
+	CACertPath string
+	// ClientCertPath/ClientKeyPath, when both set, authenticate this client
+	// to an mTLS-protected SIEM API.
+	ClientCertPath string
+	ClientKeyPath  string
+	// Insecure disables TLS certificate verification. Defaults to false;
+	// only meant for talking to a SIEM with a self-signed cert in dev.
+	Insecure bool
+	// ProxyURL, when set, routes every request through this proxy instead
+	// of the HTTP(S)_PROXY environment variables.
+	ProxyURL string
+}
+
+// newHTTPClient builds a single *http.Client meant to be shared across all
+// workers, so TCP connections to the SIEM endpoint are reused instead of a
+// new transport being dialed for every request.
+func newHTTPClient(cfg httpClientConfig) (*http.Client, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.Insecure}
+
+	if cfg.CACertPath != "" {
+		caCert, err := os.ReadFile(cfg.CACertPath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA cert %s: %w", cfg.CACertPath, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("error parsing CA cert %s: no certificates found", cfg.CACertPath)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.ClientCertPath != "" || cfg.ClientKeyPath != "" {
+		if cfg.ClientCertPath == "" || cfg.ClientKeyPath == "" {
+			return nil, fmt.Errorf("both -client-cert and -client-key must be set for mTLS")
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCertPath, cfg.ClientKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport := &http.Transport{
+		TLSClientConfig:     tlsConfig,
+		MaxIdleConns:        cfg.MaxIdleConns,
+		MaxIdleConnsPerHost: cfg.MaxIdleConnsPerHost,
+		IdleConnTimeout:     cfg.IdleConnTimeout,
+		Proxy:               http.ProxyFromEnvironment,
+	}
+
+	if cfg.ProxyURL != "" {
+		proxyURL, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing proxy URL %s: %w", cfg.ProxyURL, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   cfg.Timeout,
+	}, nil
+}
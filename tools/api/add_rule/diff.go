@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// FieldDiff describes a single field-level difference between a locally
+// computed correlation and what is currently deployed in the SIEM.
+type FieldDiff struct {
+	Field string      `json:"field"`
+	Op    string      `json:"op"` // "added", "removed", or "changed"
+	Old   interface{} `json:"old,omitempty"`
+	New   interface{} `json:"new,omitempty"`
+}
+
+// diffCorrelations compares local against remote (remote is nil when the
+// rule does not exist yet, in which case every local field is reported as
+// added) and returns every field-level difference, nested Data fields
+// included.
+func diffCorrelations(remote, local *Correlation) ([]FieldDiff, error) {
+	remoteMap, err := toJSONMap(remote)
+	if err != nil {
+		return nil, err
+	}
+	localMap, err := toJSONMap(local)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []FieldDiff
+	diffMaps("", remoteMap, localMap, &diffs)
+	return diffs, nil
+}
+
+// toJSONMap round-trips v through JSON to get a generic map representation
+// suitable for field-by-field comparison. A nil v yields an empty map.
+func toJSONMap(v interface{}) (map[string]interface{}, error) {
+	if v == nil || reflect.ValueOf(v).IsNil() {
+		return map[string]interface{}{}, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// diffMaps recursively compares remote and local, appending a FieldDiff to
+// diffs for every addition, removal, or change. Nested objects (e.g. Data)
+// are walked with a dotted field path such as "Data.TimeFrameValue".
+func diffMaps(prefix string, remote, local map[string]interface{}, diffs *[]FieldDiff) {
+	for key, localVal := range local {
+		field := fieldPath(prefix, key)
+
+		remoteVal, existsInRemote := remote[key]
+		if !existsInRemote {
+			*diffs = append(*diffs, FieldDiff{Field: field, Op: "added", New: localVal})
+			continue
+		}
+
+		localNested, localIsMap := localVal.(map[string]interface{})
+		remoteNested, remoteIsMap := remoteVal.(map[string]interface{})
+		if localIsMap && remoteIsMap {
+			diffMaps(field, remoteNested, localNested, diffs)
+			continue
+		}
+
+		if !reflect.DeepEqual(remoteVal, localVal) {
+			*diffs = append(*diffs, FieldDiff{Field: field, Op: "changed", Old: remoteVal, New: localVal})
+		}
+	}
+
+	for key, remoteVal := range remote {
+		if _, ok := local[key]; !ok {
+			*diffs = append(*diffs, FieldDiff{Field: fieldPath(prefix, key), Op: "removed", Old: remoteVal})
+		}
+	}
+}
+
+func fieldPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -0,0 +1,61 @@
+package main
+
+import "testing"
+
+func findDiff(diffs []FieldDiff, field string) *FieldDiff {
+	for i := range diffs {
+		if diffs[i].Field == field {
+			return &diffs[i]
+		}
+	}
+	return nil
+}
+
+func TestDiffCorrelationsNewRule(t *testing.T) {
+	local := &Correlation{Name: "new-rule", RiskLevel: 50}
+
+	diffs, err := diffCorrelations(nil, local)
+	if err != nil {
+		t.Fatalf("diffCorrelations returned error: %v", err)
+	}
+
+	if d := findDiff(diffs, "Name"); d == nil || d.Op != "added" {
+		t.Errorf("Name diff = %v, want an \"added\" entry", d)
+	}
+}
+
+func TestDiffCorrelationsChangedAndNestedField(t *testing.T) {
+	remote := &Correlation{
+		Name:      "existing-rule",
+		RiskLevel: 50,
+		Data:      Data{TimeFrameValue: 5, TimeFrameType: "minutes"},
+	}
+	local := &Correlation{
+		Name:      "existing-rule",
+		RiskLevel: 90,
+		Data:      Data{TimeFrameValue: 10, TimeFrameType: "minutes"},
+	}
+
+	diffs, err := diffCorrelations(remote, local)
+	if err != nil {
+		t.Fatalf("diffCorrelations returned error: %v", err)
+	}
+
+	if d := findDiff(diffs, "Name"); d != nil {
+		t.Errorf("Name diff = %v, want no diff for an unchanged field", d)
+	}
+
+	riskDiff := findDiff(diffs, "RiskLevel")
+	if riskDiff == nil || riskDiff.Op != "changed" {
+		t.Fatalf("RiskLevel diff = %v, want a \"changed\" entry", riskDiff)
+	}
+
+	tfDiff := findDiff(diffs, "Data.TimeFrameValue")
+	if tfDiff == nil || tfDiff.Op != "changed" {
+		t.Fatalf("Data.TimeFrameValue diff = %v, want a \"changed\" entry", tfDiff)
+	}
+
+	if d := findDiff(diffs, "Data.TimeFrameType"); d != nil {
+		t.Errorf("Data.TimeFrameType diff = %v, want no diff for an unchanged nested field", d)
+	}
+}
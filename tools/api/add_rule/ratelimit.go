@@ -0,0 +1,54 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter is a simple token-bucket limiter used to cap the number of
+// requests per second sent to the SIEM endpoint.
+type rateLimiter struct {
+	mu       sync.Mutex
+	tokens   float64
+	max      float64
+	rate     float64 // tokens added per second
+	lastFill time.Time
+}
+
+// newRateLimiter returns a rateLimiter that allows up to rps requests per
+// second. A non-positive rps disables limiting entirely.
+func newRateLimiter(rps float64) *rateLimiter {
+	if rps <= 0 {
+		return nil
+	}
+	return &rateLimiter{
+		tokens:   rps,
+		max:      rps,
+		rate:     rps,
+		lastFill: time.Now(),
+	}
+}
+
+// Wait blocks until a token is available. A nil rateLimiter never blocks.
+func (r *rateLimiter) Wait() {
+	if r == nil {
+		return
+	}
+	for {
+		r.mu.Lock()
+		now := time.Now()
+		r.tokens += now.Sub(r.lastFill).Seconds() * r.rate
+		if r.tokens > r.max {
+			r.tokens = r.max
+		}
+		r.lastFill = now
+
+		if r.tokens >= 1 {
+			r.tokens--
+			r.mu.Unlock()
+			return
+		}
+		r.mu.Unlock()
+		time.Sleep(time.Duration(float64(time.Second) / r.rate))
+	}
+}
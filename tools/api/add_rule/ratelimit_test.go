@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewRateLimiterDisabled(t *testing.T) {
+	if rl := newRateLimiter(0); rl != nil {
+		t.Errorf("newRateLimiter(0) = %v, want nil", rl)
+	}
+	if rl := newRateLimiter(-1); rl != nil {
+		t.Errorf("newRateLimiter(-1) = %v, want nil", rl)
+	}
+}
+
+func TestRateLimiterWaitThrottles(t *testing.T) {
+	rl := newRateLimiter(100) // 100 rps, so a burst of 10 should take ~90ms at most
+
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		rl.Wait()
+	}
+	elapsed := time.Since(start)
+
+	if elapsed > 500*time.Millisecond {
+		t.Errorf("10 calls at 100rps took %v, want well under 500ms", elapsed)
+	}
+}
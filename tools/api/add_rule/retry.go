@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// retryConfig controls the exponential backoff applied to retryable HTTP
+// responses (429 and 5xx).
+type retryConfig struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// defaultRetryConfig is used for every request issued by SaveRequest and
+// GetRequest.
+var defaultRetryConfig = retryConfig{
+	MaxRetries: 5,
+	BaseDelay:  500 * time.Millisecond,
+	MaxDelay:   30 * time.Second,
+}
+
+// isRetryableStatus reports whether code should trigger a retry.
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// retryDelay computes the delay before the given 0-based attempt, honoring
+// a Retry-After header when present and otherwise falling back to
+// exponential backoff with full jitter.
+func retryDelay(cfg retryConfig, attempt int, retryAfter string) time.Duration {
+	if retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second
+		}
+		if t, err := http.ParseTime(retryAfter); err == nil {
+			if d := time.Until(t); d > 0 {
+				return d
+			}
+		}
+	}
+
+	delay := cfg.BaseDelay * time.Duration(1<<uint(attempt))
+	if delay > cfg.MaxDelay {
+		delay = cfg.MaxDelay
+	}
+	return time.Duration(rand.Int63n(int64(delay)))
+}
+
+// doWithRetry executes req via client, retrying on 429/5xx responses with
+// exponential backoff and jitter up to cfg.MaxRetries times. req must have
+// a non-nil GetBody (true for requests built with http.NewRequest over a
+// bytes.Reader, bytes.Buffer or strings.Reader) so the body can be replayed
+// on each attempt.
+func doWithRetry(client *http.Client, req *http.Request, cfg retryConfig) (*http.Response, error) {
+	var lastErr error
+	for attempt := 0; attempt <= cfg.MaxRetries; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("error rewinding request body for retry: %w", err)
+			}
+			req.Body = body
+		}
+
+		res, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+		} else if !isRetryableStatus(res.StatusCode) {
+			return res, nil
+		} else {
+			retryAfter := res.Header.Get("Retry-After")
+			res.Body.Close()
+			lastErr = fmt.Errorf("retryable status %d from %s", res.StatusCode, req.URL)
+			if attempt < cfg.MaxRetries {
+				time.Sleep(retryDelay(cfg, attempt, retryAfter))
+			}
+			continue
+		}
+
+		if attempt < cfg.MaxRetries {
+			time.Sleep(retryDelay(cfg, attempt, ""))
+		}
+	}
+	return nil, lastErr
+}
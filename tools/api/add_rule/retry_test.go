@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIsRetryableStatus(t *testing.T) {
+	cases := []struct {
+		code int
+		want bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusBadRequest, false},
+		{http.StatusTooManyRequests, true},
+		{http.StatusInternalServerError, true},
+		{http.StatusBadGateway, true},
+	}
+
+	for _, c := range cases {
+		if got := isRetryableStatus(c.code); got != c.want {
+			t.Errorf("isRetryableStatus(%d) = %v, want %v", c.code, got, c.want)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	cfg := retryConfig{MaxRetries: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	t.Run("honors a numeric Retry-After", func(t *testing.T) {
+		if got := retryDelay(cfg, 0, "2"); got != 2*time.Second {
+			t.Errorf("retryDelay with Retry-After=2 = %v, want 2s", got)
+		}
+	})
+
+	t.Run("falls back to bounded jittered backoff without Retry-After", func(t *testing.T) {
+		for attempt := 0; attempt < cfg.MaxRetries; attempt++ {
+			got := retryDelay(cfg, attempt, "")
+			if got < 0 || got > cfg.MaxDelay {
+				t.Errorf("retryDelay(attempt=%d) = %v, want within [0, %v]", attempt, got, cfg.MaxDelay)
+			}
+		}
+	})
+}
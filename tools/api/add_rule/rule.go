@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// AlterixRule is the typed shape of the "query" object inside an input
+// JSON file. It covers the fields add_rule itself consumes plus the ones
+// commonly found in Sigma-generated rules, so raw Sigma YAML converted to
+// JSON can eventually be fed straight in. Status, Author, References and
+// Falsepositives are decoded but not yet consumed by any code path; they
+// exist so a template or future feature can read them without a schema
+// change.
+type AlterixRule struct {
+	Name        string   `json:"Name"`
+	Description string   `json:"Description"`
+	Tags        []string `json:"Tags,omitempty"`
+	RiskLevel   int      `json:"RiskLevel"`
+	ID          string   `json:"ID"`
+	Query       string   `json:"Query"`
+	// Level is the Sigma `level` field (e.g. "critical"); a -template
+	// override can match on it via CorrelationMatch.Level.
+	Level          string   `json:"Level,omitempty"`
+	Status         string   `json:"Status,omitempty"`
+	Author         string   `json:"Author,omitempty"`
+	References     []string `json:"References,omitempty"`
+	Falsepositives []string `json:"Falsepositives,omitempty"`
+}
+
+// AlterixPayload is the top-level shape of an input JSON file.
+type AlterixPayload struct {
+	Query AlterixRule `json:"query"`
+}
+
+// Validate checks that every field add_rule requires to build a
+// correlation is present, returning a descriptive error naming the
+// offending field.
+func (r AlterixRule) Validate() error {
+	switch {
+	case r.Name == "":
+		return fmt.Errorf("missing required field %q", "query.Name")
+	case r.ID == "":
+		return fmt.Errorf("missing required field %q", "query.ID")
+	case r.Query == "":
+		return fmt.Errorf("missing required field %q", "query.Query")
+	}
+	return nil
+}
+
+// loadAlterixRule decodes path into a typed AlterixRule, validating
+// required fields and returning errors that name the offending file and
+// field rather than silently falling back to zero values. When strict is
+// true, unrecognized fields in the "query" object are rejected instead of
+// being ignored.
+func loadAlterixRule(path string, strict bool) (AlterixRule, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return AlterixRule{}, fmt.Errorf("%s: error opening file: %w", path, err)
+	}
+	defer file.Close()
+
+	var payload AlterixPayload
+	decoder := json.NewDecoder(file)
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(&payload); err != nil {
+		return AlterixRule{}, fmt.Errorf("%s: error decoding JSON: %w", path, err)
+	}
+
+	if err := payload.Query.Validate(); err != nil {
+		return AlterixRule{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return payload.Query, nil
+}
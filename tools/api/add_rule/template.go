@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CorrelationDefaults mirrors the Correlation/Data fields a template may
+// supply. Every field is a pointer so a template can leave a field unset,
+// in which case the built-in default (or a previous override) is kept.
+type CorrelationDefaults struct {
+	MaxAlertCount             *int    `json:"MaxAlertCount,omitempty" yaml:"MaxAlertCount,omitempty"`
+	RiskLevel                 *int    `json:"RiskLevel,omitempty" yaml:"RiskLevel,omitempty"`
+	CorrelationType           *string `json:"CorrelationType,omitempty" yaml:"CorrelationType,omitempty"`
+	Enabled                   *bool   `json:"Enabled,omitempty" yaml:"Enabled,omitempty"`
+	TimeFrameValue            *int    `json:"TimeFrameValue,omitempty" yaml:"TimeFrameValue,omitempty"`
+	TimeFrameType             *string `json:"TimeFrameType,omitempty" yaml:"TimeFrameType,omitempty"`
+	RuleType                  *string `json:"RuleType,omitempty" yaml:"RuleType,omitempty"`
+	QueryCorrelationAlertType *string `json:"QueryCorrelationAlertType,omitempty" yaml:"QueryCorrelationAlertType,omitempty"`
+}
+
+// CorrelationMatch selects which rules a CorrelationOverride applies to.
+// Exactly one of the fields is expected to be set, checked in this order: a
+// non-empty ID must match the rule's ID exactly; a non-empty Level must
+// match the rule's Sigma `level` field case-insensitively (e.g. "critical");
+// a non-empty Tag is matched against every rule tag with path.Match, so
+// patterns such as "attack.*" work the same way Sigma tags do.
+type CorrelationMatch struct {
+	ID    string `json:"id,omitempty" yaml:"id,omitempty"`
+	Level string `json:"level,omitempty" yaml:"level,omitempty"`
+	Tag   string `json:"tag,omitempty" yaml:"tag,omitempty"`
+}
+
+// CorrelationOverride applies Set on top of the template defaults for every
+// rule matched by Match.
+type CorrelationOverride struct {
+	Match CorrelationMatch    `json:"match" yaml:"match"`
+	Set   CorrelationDefaults `json:"set" yaml:"set"`
+}
+
+// CorrelationTemplate is the top-level shape of a -template file: a set of
+// defaults applied to every rule, plus per-rule overrides layered on top in
+// order.
+type CorrelationTemplate struct {
+	Defaults  CorrelationDefaults   `json:"defaults" yaml:"defaults"`
+	Overrides []CorrelationOverride `json:"overrides,omitempty" yaml:"overrides,omitempty"`
+}
+
+// builtinDefaults mirrors the values add_rule hardcoded before templates
+// existed, so running without -template behaves exactly as before.
+func builtinDefaults() CorrelationDefaults {
+	return CorrelationDefaults{
+		MaxAlertCount:             intPtr(5),
+		CorrelationType:           stringPtr("Interface IQueryCorrelation"),
+		Enabled:                   boolPtr(false),
+		TimeFrameValue:            intPtr(5),
+		TimeFrameType:             stringPtr("minutes"),
+		RuleType:                  stringPtr("any"),
+		QueryCorrelationAlertType: stringPtr("WhenOneOrMoreRow"),
+	}
+}
+
+// loadTemplate reads a -template file, parsing it as YAML or JSON based on
+// its extension (.json vs everything else).
+func loadTemplate(templatePath string) (*CorrelationTemplate, error) {
+	data, err := os.ReadFile(templatePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading template file %s: %w", templatePath, err)
+	}
+
+	var tpl CorrelationTemplate
+	if strings.EqualFold(path.Ext(templatePath), ".json") {
+		if err := json.Unmarshal(data, &tpl); err != nil {
+			return nil, fmt.Errorf("error parsing template file %s as JSON: %w", templatePath, err)
+		}
+	} else {
+		if err := yaml.Unmarshal(data, &tpl); err != nil {
+			return nil, fmt.Errorf("error parsing template file %s as YAML: %w", templatePath, err)
+		}
+	}
+	return &tpl, nil
+}
+
+// applyDefaults copies every non-nil field of override onto base.
+func applyDefaults(base CorrelationDefaults, override CorrelationDefaults) CorrelationDefaults {
+	if override.MaxAlertCount != nil {
+		base.MaxAlertCount = override.MaxAlertCount
+	}
+	if override.RiskLevel != nil {
+		base.RiskLevel = override.RiskLevel
+	}
+	if override.CorrelationType != nil {
+		base.CorrelationType = override.CorrelationType
+	}
+	if override.Enabled != nil {
+		base.Enabled = override.Enabled
+	}
+	if override.TimeFrameValue != nil {
+		base.TimeFrameValue = override.TimeFrameValue
+	}
+	if override.TimeFrameType != nil {
+		base.TimeFrameType = override.TimeFrameType
+	}
+	if override.RuleType != nil {
+		base.RuleType = override.RuleType
+	}
+	if override.QueryCorrelationAlertType != nil {
+		base.QueryCorrelationAlertType = override.QueryCorrelationAlertType
+	}
+	return base
+}
+
+// resolveDefaults computes the effective CorrelationDefaults for a rule
+// identified by ruleID/level/tags: builtin defaults, then the template's
+// defaults, then every matching override, in order.
+func resolveDefaults(tpl *CorrelationTemplate, ruleID, level string, tags []string) CorrelationDefaults {
+	resolved := builtinDefaults()
+	if tpl == nil {
+		return resolved
+	}
+
+	resolved = applyDefaults(resolved, tpl.Defaults)
+	for _, override := range tpl.Overrides {
+		if matchesOverride(override.Match, ruleID, level, tags) {
+			resolved = applyDefaults(resolved, override.Set)
+		}
+	}
+	return resolved
+}
+
+// matchesOverride reports whether an override's match criteria select the
+// rule identified by ruleID/level/tags.
+func matchesOverride(match CorrelationMatch, ruleID, level string, tags []string) bool {
+	if match.ID != "" {
+		return match.ID == ruleID
+	}
+	if match.Level != "" {
+		return strings.EqualFold(match.Level, level)
+	}
+	if match.Tag != "" {
+		for _, tag := range tags {
+			if ok, err := path.Match(match.Tag, tag); err == nil && ok {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+func intPtr(v int) *int          { return &v }
+func stringPtr(v string) *string { return &v }
+func boolPtr(v bool) *bool       { return &v }
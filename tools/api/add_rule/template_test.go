@@ -0,0 +1,55 @@
+package main
+
+import "testing"
+
+func TestMatchesOverride(t *testing.T) {
+	cases := []struct {
+		name          string
+		match         CorrelationMatch
+		ruleID, level string
+		tags          []string
+		want          bool
+	}{
+		{"id match", CorrelationMatch{ID: "rule-1"}, "rule-1", "critical", nil, true},
+		{"id mismatch", CorrelationMatch{ID: "rule-1"}, "rule-2", "critical", nil, false},
+		{"level match is case-insensitive", CorrelationMatch{Level: "Critical"}, "rule-1", "critical", nil, true},
+		{"level mismatch", CorrelationMatch{Level: "critical"}, "rule-1", "high", nil, false},
+		{"tag glob match", CorrelationMatch{Tag: "attack.*"}, "rule-1", "", []string{"attack.execution"}, true},
+		{"tag glob mismatch", CorrelationMatch{Tag: "attack.*"}, "rule-1", "", []string{"other"}, false},
+		{"empty match matches nothing", CorrelationMatch{}, "rule-1", "critical", []string{"attack.execution"}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := matchesOverride(c.match, c.ruleID, c.level, c.tags); got != c.want {
+				t.Errorf("matchesOverride(%+v, %q, %q, %v) = %v, want %v", c.match, c.ruleID, c.level, c.tags, got, c.want)
+			}
+		})
+	}
+}
+
+func TestResolveDefaultsAppliesOverridesInOrder(t *testing.T) {
+	tpl := &CorrelationTemplate{
+		Defaults: CorrelationDefaults{Enabled: boolPtr(false)},
+		Overrides: []CorrelationOverride{
+			{Match: CorrelationMatch{Level: "critical"}, Set: CorrelationDefaults{Enabled: boolPtr(true), RiskLevel: intPtr(90)}},
+		},
+	}
+
+	resolved := resolveDefaults(tpl, "rule-1", "critical", nil)
+	if resolved.Enabled == nil || !*resolved.Enabled {
+		t.Errorf("Enabled = %v, want true", resolved.Enabled)
+	}
+	if resolved.RiskLevel == nil || *resolved.RiskLevel != 90 {
+		t.Errorf("RiskLevel = %v, want 90", resolved.RiskLevel)
+	}
+	// Fields neither overridden nor set in the template keep their builtin default.
+	if resolved.TimeFrameType == nil || *resolved.TimeFrameType != "minutes" {
+		t.Errorf("TimeFrameType = %v, want minutes", resolved.TimeFrameType)
+	}
+
+	resolvedHigh := resolveDefaults(tpl, "rule-2", "high", nil)
+	if resolvedHigh.Enabled == nil || *resolvedHigh.Enabled {
+		t.Errorf("Enabled for non-matching level = %v, want false", resolvedHigh.Enabled)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sync"
+)
+
+// fileResult records the outcome of processing a single input JSON file.
+type fileResult struct {
+	File    string      `json:"file"`
+	Success bool        `json:"success"`
+	Error   string      `json:"error,omitempty"`
+	Diff    []FieldDiff `json:"diff,omitempty"`
+}
+
+// summaryReport is written to responseDirectory/_summary.json once every
+// worker has finished, so a pipeline can assert on ingest results without
+// scraping stdout.
+type summaryReport struct {
+	Total   int          `json:"total"`
+	Success int          `json:"success"`
+	Failed  int          `json:"failed"`
+	Results []fileResult `json:"results"`
+}
+
+// processFiles fans jsonFiles out across concurrency workers, all sharing
+// client and limiter, and returns a summaryReport of the outcome.
+func processFiles(jsonFiles []string, client *http.Client, limiter *rateLimiter, tpl *CorrelationTemplate, urlHostname, xAPIKey, responseDirectory string, concurrency int, dryRun, strict bool) summaryReport {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	paths := make(chan string)
+	results := make(chan fileResult)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				results <- processFile(path, client, limiter, tpl, urlHostname, xAPIKey, responseDirectory, dryRun, strict)
+			}
+		}()
+	}
+
+	go func() {
+		for _, path := range jsonFiles {
+			paths <- path
+		}
+		close(paths)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var report summaryReport
+	for res := range results {
+		report.Total++
+		if res.Success {
+			report.Success++
+		} else {
+			report.Failed++
+		}
+		report.Results = append(report.Results, res)
+	}
+	return report
+}
+
+// processFile decodes a single input JSON file and submits it to the SIEM,
+// rate-limiting each outbound request. In dry-run mode it instead diffs the
+// computed correlation against the deployed version and writes the diff
+// next to the response directory.
+func processFile(path string, client *http.Client, limiter *rateLimiter, tpl *CorrelationTemplate, urlHostname, xAPIKey, responseDirectory string, dryRun, strict bool) fileResult {
+	rule, err := loadAlterixRule(path, strict)
+	if err != nil {
+		return fileResult{File: path, Error: err.Error()}
+	}
+
+	savePayload, getPayload := buildPayloads(rule, tpl)
+
+	saveURLPath := "/api/DpConnection/CallByInterfaceApi/?interfaceCode=ICSiemManagerCorrelationAct&methodName=AddOrUpdateCorrelation&culture=en"
+	getURLPath := "/api/DpConnection/CallByInterfaceApi/?interfaceCode=ICSiemManagerCorrelationAct&methodName=GetCorrelationList&culture=en"
+	saveFullURL := fmt.Sprintf("https://%s%s", urlHostname, saveURLPath)
+	getFullURL := fmt.Sprintf("https://%s%s", urlHostname, getURLPath)
+
+	if dryRun {
+		limiter.Wait()
+		remote, err := FetchCorrelation(client, xAPIKey, getFullURL, "POST", getPayload)
+		if err != nil {
+			return fileResult{File: path, Error: err.Error()}
+		}
+
+		diffs, err := diffCorrelations(remote, &savePayload.Correlation)
+		if err != nil {
+			return fileResult{File: path, Error: fmt.Sprintf("error diffing correlation: %v", err)}
+		}
+
+		diffPath := filepath.Join(responseDirectory, fmt.Sprintf("%s_diff.json", savePayload.Correlation.Name))
+		if err := writeJSONToFile(diffPath, diffs); err != nil {
+			return fileResult{File: path, Error: fmt.Sprintf("error writing diff to file: %v", err)}
+		}
+
+		fmt.Printf("Dry run: %d field(s) would change for %s (diff saved to %s)\n", len(diffs), savePayload.Correlation.Name, diffPath)
+		return fileResult{File: path, Success: true, Diff: diffs}
+	}
+
+	limiter.Wait()
+	if err := GetRequest(client, xAPIKey, getFullURL, "POST", getPayload); err != nil {
+		return fileResult{File: path, Error: err.Error()}
+	}
+
+	limiter.Wait()
+	if err := SaveRequest(client, xAPIKey, saveFullURL, "POST", responseDirectory, savePayload); err != nil {
+		return fileResult{File: path, Error: err.Error()}
+	}
+
+	return fileResult{File: path, Success: true}
+}
+
+// writeSummary writes report to responseDirectory/_summary.json.
+func writeSummary(responseDirectory string, report summaryReport) error {
+	return writeJSONToFile(filepath.Join(responseDirectory, "_summary.json"), report)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newStubSIEMServer returns an httptest.Server that answers GetCorrelationList
+// with an empty rule list and AddOrUpdateCorrelation with a successful save,
+// standing in for the real SIEM endpoints add_rule talks to.
+func newStubSIEMServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("methodName") {
+		case "GetCorrelationList":
+			json.NewEncoder(w).Encode(map[string]interface{}{"Items": []interface{}{}})
+		case "AddOrUpdateCorrelation":
+			json.NewEncoder(w).Encode(map[string]interface{}{"Status": true})
+		default:
+			http.Error(w, "unexpected methodName", http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// writeRuleFile writes a minimal valid input JSON file to dir and returns its path.
+func writeRuleFile(t *testing.T, dir, name, ruleID string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	body := `{"query":{"Name":"` + name + `","ID":"` + ruleID + `","Query":"select 1"}}`
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatalf("writing rule file: %v", err)
+	}
+	return path
+}
+
+func TestProcessFilesSummary(t *testing.T) {
+	server := newStubSIEMServer(t)
+	urlHostname := strings.TrimPrefix(server.URL, "https://")
+
+	dir := t.TempDir()
+	ok1 := writeRuleFile(t, dir, "ok1.json", "rule-1")
+	ok2 := writeRuleFile(t, dir, "ok2.json", "rule-2")
+
+	badPath := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(badPath, []byte(`{"query":{"Name":"missing-id"}}`), 0o644); err != nil {
+		t.Fatalf("writing bad rule file: %v", err)
+	}
+
+	responseDir := t.TempDir()
+	report := processFiles([]string{ok1, ok2, badPath}, server.Client(), nil, nil, urlHostname, "test-key", responseDir, 2, false, false)
+
+	if report.Total != 3 {
+		t.Errorf("Total = %d, want 3", report.Total)
+	}
+	if report.Success != 2 {
+		t.Errorf("Success = %d, want 2", report.Success)
+	}
+	if report.Failed != 1 {
+		t.Errorf("Failed = %d, want 1", report.Failed)
+	}
+	if len(report.Results) != 3 {
+		t.Errorf("len(Results) = %d, want 3", len(report.Results))
+	}
+
+	var failures int
+	for _, res := range report.Results {
+		if !res.Success {
+			failures++
+			if res.Error == "" {
+				t.Errorf("failed result for %s has no Error message", res.File)
+			}
+		}
+	}
+	if failures != 1 {
+		t.Errorf("found %d failed results, want 1", failures)
+	}
+}
+
+func TestProcessFilesEmptyInput(t *testing.T) {
+	report := processFiles(nil, nil, nil, nil, "example.com", "test-key", t.TempDir(), 4, false, false)
+
+	if report.Total != 0 || report.Success != 0 || report.Failed != 0 {
+		t.Errorf("report = %+v, want all-zero report for no input files", report)
+	}
+}